@@ -0,0 +1,39 @@
+//go:build selfupdate
+
+// Copyright (c) 2019-2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/singularityware/singularity/pkg/selfupdate"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	singularityCmd.AddCommand(selfUpdateCmd)
+}
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update singularity to the latest signed release",
+	Long:  "Download the latest release binary from GitHub, verify it against the embedded release signing key, and replace the running binary.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := selfupdate.LatestReleaseOptions()
+		if err != nil {
+			return err
+		}
+
+		if err := selfupdate.Update(opts); err != nil {
+			return err
+		}
+
+		fmt.Println("singularity has been updated")
+		return nil
+	},
+}
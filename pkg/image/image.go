@@ -0,0 +1,212 @@
+// Copyright (c) 2019-2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+// Package image provides access to SIF (Singularity Image Format) images:
+// loading/unloading an image, looking up its data objects (partitions,
+// signatures, encrypted payloads), and adding or removing data objects.
+package image
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+)
+
+// Data object types stored in a SIF image's descriptor table.
+const (
+	SIF_DATA_PARTITION = iota + 1
+	SIF_DATA_SIGNATURE
+	SIF_DATA_CRYPT_MESSAGE
+)
+
+// SIF_DEFAULT_GROUP is the descriptor group ID used for an image's primary
+// partition and its associated signature/encryption data objects.
+const SIF_DEFAULT_GROUP = 1
+
+// Eleminfo describes a single data object within a SIF image: its type,
+// group, and the byte range in the image's mapped data it occupies.
+type Eleminfo struct {
+	Datatype    int32
+	Group       int32
+	Fileoff     int64
+	Filelen     int64
+	Fingerprint [20]byte
+
+	// payload holds bytes staged by Init* until SifPutDataObj commits them
+	// to the image; it is never itself persisted.
+	payload []byte
+}
+
+// FileOff returns the data object's byte offset within Mapstart().
+func (e *Eleminfo) FileOff() int64 { return e.Fileoff }
+
+// FileLen returns the data object's length in bytes.
+func (e *Eleminfo) FileLen() int64 { return e.Filelen }
+
+// InitSignature stages e as a detached signature over part, recorded under
+// part's group, so a later SifPutDataObj call adds it to the image.
+func (e *Eleminfo) InitSignature(fingerprint [20]byte, signature []byte, part *Eleminfo) {
+	e.Datatype = SIF_DATA_SIGNATURE
+	e.Group = part.Group
+	e.Fingerprint = fingerprint
+	e.payload = append([]byte(nil), signature...)
+}
+
+// InitEncryption stages e as an encrypted payload replacing part's
+// plaintext data, recorded under part's group, so a later SifPutDataObj
+// call adds it to the image.
+func (e *Eleminfo) InitEncryption(ciphertext []byte, part *Eleminfo) {
+	e.Datatype = SIF_DATA_CRYPT_MESSAGE
+	e.Group = part.Group
+	e.payload = append([]byte(nil), ciphertext...)
+}
+
+// Sifinfo is a loaded SIF image: its data object descriptor table and the
+// contiguous byte buffer those descriptors index into via Mapstart/FileOff/
+// FileLen.
+type Sifinfo struct {
+	path        string
+	data        []byte
+	descriptors []Eleminfo
+}
+
+// Mapstart returns the image's mapped data buffer, into which every
+// descriptor's FileOff/FileLen indexes.
+func (s *Sifinfo) Mapstart() []byte { return s.data }
+
+// onDiskImage is the on-disk encoding of a Sifinfo: a descriptor table
+// followed by the raw bytes it indexes into.
+type onDiskImage struct {
+	Descriptors []Eleminfo
+	Data        []byte
+}
+
+// SifLoad opens the SIF image at path and populates sinfo with its
+// descriptor table and mapped data. flags is reserved for future use
+// (e.g. read-only vs read-write mapping) and is currently ignored.
+func SifLoad(path string, sinfo *Sifinfo, flags int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open SIF image %s: %s", path, err)
+	}
+	defer f.Close()
+
+	var img onDiskImage
+	if err := gob.NewDecoder(f).Decode(&img); err != nil {
+		return fmt.Errorf("failed to read SIF image %s: %s", path, err)
+	}
+
+	sinfo.path = path
+	sinfo.data = img.Data
+	sinfo.descriptors = img.Descriptors
+	return nil
+}
+
+// SifUnload releases sinfo's in-memory state. Any changes made via
+// SifPutDataObj/SifDeleteDataObj are already persisted at the time they are
+// made, so SifUnload does not itself write to disk.
+func SifUnload(sinfo *Sifinfo) error {
+	sinfo.data = nil
+	sinfo.descriptors = nil
+	return nil
+}
+
+// save persists sinfo's current descriptor table and data back to its
+// backing file.
+func (s *Sifinfo) save() error {
+	f, err := os.Create(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to write SIF image %s: %s", s.path, err)
+	}
+	defer f.Close()
+
+	img := onDiskImage{Descriptors: s.descriptors, Data: s.data}
+	if err := gob.NewEncoder(f).Encode(&img); err != nil {
+		return fmt.Errorf("failed to write SIF image %s: %s", s.path, err)
+	}
+	return nil
+}
+
+// SifGetPartition returns the data partition data object belonging to
+// group.
+func SifGetPartition(sinfo *Sifinfo, group int32) (*Eleminfo, error) {
+	for i := range sinfo.descriptors {
+		d := &sinfo.descriptors[i]
+		if d.Datatype == SIF_DATA_PARTITION && d.Group == group {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("no partition found for group %d", group)
+}
+
+// SifGetSignature returns the first signature data object found in sinfo.
+func SifGetSignature(sinfo *Sifinfo) (*Eleminfo, error) {
+	sigs, err := SifGetSignatures(sinfo)
+	if err != nil {
+		return nil, err
+	}
+	return sigs[0], nil
+}
+
+// SifGetSignatures returns every signature data object in sinfo, one per
+// signer, in on-disk order -- unlike SifGetSignature, which only returns
+// the first.
+func SifGetSignatures(sinfo *Sifinfo) ([]*Eleminfo, error) {
+	var sigs []*Eleminfo
+	for i := range sinfo.descriptors {
+		d := &sinfo.descriptors[i]
+		if d.Datatype == SIF_DATA_SIGNATURE {
+			sigs = append(sigs, d)
+		}
+	}
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("no signature data object found")
+	}
+	return sigs, nil
+}
+
+// SifGetEncryption returns the encrypted payload data object in sinfo, as
+// added by signing.Encrypt.
+func SifGetEncryption(sinfo *Sifinfo) (*Eleminfo, error) {
+	for i := range sinfo.descriptors {
+		d := &sinfo.descriptors[i]
+		if d.Datatype == SIF_DATA_CRYPT_MESSAGE {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("no encrypted data object found")
+}
+
+// SifPutDataObj appends e's staged payload (set up via InitSignature/
+// InitEncryption) to sinfo's data, records e in its descriptor table, and
+// persists the result.
+func SifPutDataObj(e *Eleminfo, sinfo *Sifinfo) error {
+	e.Fileoff = int64(len(sinfo.data))
+	e.Filelen = int64(len(e.payload))
+	sinfo.data = append(sinfo.data, e.payload...)
+	sinfo.descriptors = append(sinfo.descriptors, *e)
+	return sinfo.save()
+}
+
+// SifDeleteDataObj removes e from sinfo's descriptor table and persists
+// the result, so its bytes are no longer reachable through the image.
+func SifDeleteDataObj(sinfo *Sifinfo, e *Eleminfo) error {
+	for i := range sinfo.descriptors {
+		d := &sinfo.descriptors[i]
+		if d.Datatype == e.Datatype && d.Group == e.Group && d.Fileoff == e.Fileoff && d.Filelen == e.Filelen {
+			sinfo.descriptors = append(sinfo.descriptors[:i], sinfo.descriptors[i+1:]...)
+			return sinfo.save()
+		}
+	}
+	return fmt.Errorf("data object not found in image")
+}
+
+// CByteRange returns the slice of data spanning [offset, offset+length).
+func CByteRange(data []byte, offset, length int64) ([]byte, error) {
+	if offset < 0 || length < 0 || offset+length > int64(len(data)) {
+		return nil, fmt.Errorf("byte range [%d, %d) out of bounds for %d-byte buffer", offset, offset+length, len(data))
+	}
+	return data[offset : offset+length], nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2019-2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package image
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func newTestImage(t *testing.T, partition []byte) (string, *Sifinfo) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.sif")
+	sinfo := &Sifinfo{path: path, data: append([]byte(nil), partition...)}
+	sinfo.descriptors = []Eleminfo{{
+		Datatype: SIF_DATA_PARTITION,
+		Group:    SIF_DEFAULT_GROUP,
+		Fileoff:  0,
+		Filelen:  int64(len(partition)),
+	}}
+	if err := sinfo.save(); err != nil {
+		t.Fatalf("failed to create test image: %s", err)
+	}
+	return path, sinfo
+}
+
+func TestSifPutAndGetSignatures(t *testing.T) {
+	path, _ := newTestImage(t, []byte("plaintext payload"))
+
+	var sinfo Sifinfo
+	if err := SifLoad(path, &sinfo, 0); err != nil {
+		t.Fatalf("SifLoad failed: %s", err)
+	}
+	defer SifUnload(&sinfo)
+
+	part, err := SifGetPartition(&sinfo, SIF_DEFAULT_GROUP)
+	if err != nil {
+		t.Fatalf("SifGetPartition failed: %s", err)
+	}
+
+	var sig1, sig2 Eleminfo
+	sig1.InitSignature([20]byte{1}, []byte("signature one"), part)
+	if err := SifPutDataObj(&sig1, &sinfo); err != nil {
+		t.Fatalf("SifPutDataObj(sig1) failed: %s", err)
+	}
+	sig2.InitSignature([20]byte{2}, []byte("signature two"), part)
+	if err := SifPutDataObj(&sig2, &sinfo); err != nil {
+		t.Fatalf("SifPutDataObj(sig2) failed: %s", err)
+	}
+
+	sigs, err := SifGetSignatures(&sinfo)
+	if err != nil {
+		t.Fatalf("SifGetSignatures failed: %s", err)
+	}
+	if len(sigs) != 2 {
+		t.Fatalf("expected 2 signatures, got %d", len(sigs))
+	}
+
+	data, err := CByteRange(sinfo.Mapstart(), sigs[0].FileOff(), sigs[0].FileLen())
+	if err != nil || !bytes.Equal(data, []byte("signature one")) {
+		t.Errorf("unexpected first signature payload: %q, %v", data, err)
+	}
+
+	// Reload from disk to confirm SifPutDataObj persisted the changes.
+	var reloaded Sifinfo
+	if err := SifLoad(path, &reloaded, 0); err != nil {
+		t.Fatalf("SifLoad after put failed: %s", err)
+	}
+	if sigs, err := SifGetSignatures(&reloaded); err != nil || len(sigs) != 2 {
+		t.Fatalf("reloaded image has %d signatures, err %v; want 2", len(sigs), err)
+	}
+}
+
+func TestSifDeleteDataObj(t *testing.T) {
+	path, sinfo := newTestImage(t, []byte("plaintext payload"))
+
+	part, err := SifGetPartition(sinfo, SIF_DEFAULT_GROUP)
+	if err != nil {
+		t.Fatalf("SifGetPartition failed: %s", err)
+	}
+
+	if err := SifDeleteDataObj(sinfo, part); err != nil {
+		t.Fatalf("SifDeleteDataObj failed: %s", err)
+	}
+	if _, err := SifGetPartition(sinfo, SIF_DEFAULT_GROUP); err == nil {
+		t.Error("expected no partition after SifDeleteDataObj, found one")
+	}
+
+	var reloaded Sifinfo
+	if err := SifLoad(path, &reloaded, 0); err != nil {
+		t.Fatalf("SifLoad after delete failed: %s", err)
+	}
+	if _, err := SifGetPartition(&reloaded, SIF_DEFAULT_GROUP); err == nil {
+		t.Error("expected deleted partition to stay gone after reload")
+	}
+}
+
+func TestSifGetEncryption(t *testing.T) {
+	_, sinfo := newTestImage(t, []byte("plaintext payload"))
+
+	part, err := SifGetPartition(sinfo, SIF_DEFAULT_GROUP)
+	if err != nil {
+		t.Fatalf("SifGetPartition failed: %s", err)
+	}
+
+	var enc Eleminfo
+	enc.InitEncryption([]byte("ciphertext"), part)
+	if err := SifPutDataObj(&enc, sinfo); err != nil {
+		t.Fatalf("SifPutDataObj(enc) failed: %s", err)
+	}
+
+	got, err := SifGetEncryption(sinfo)
+	if err != nil {
+		t.Fatalf("SifGetEncryption failed: %s", err)
+	}
+	data, err := CByteRange(sinfo.Mapstart(), got.FileOff(), got.FileLen())
+	if err != nil || !bytes.Equal(data, []byte("ciphertext")) {
+		t.Errorf("unexpected encrypted payload: %q, %v", data, err)
+	}
+}
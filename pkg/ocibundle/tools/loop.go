@@ -10,6 +10,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/singularityware/singularity/pkg/signing"
 	"github.com/sylabs/singularity/pkg/util/loop"
 	"golang.org/x/sys/unix"
 )
@@ -32,3 +33,46 @@ func CreateLoop(file *os.File, offset, size uint64) (string, io.Closer, error) {
 	}
 	return fmt.Sprintf("/dev/loop%d", idx), loopDev, nil
 }
+
+// decryptedLoopCloser closes the underlying loop device closer, then
+// removes the decrypted plaintext temp file backing it so it never
+// outlives the mount.
+type decryptedLoopCloser struct {
+	io.Closer
+	file *os.File
+	path string
+}
+
+func (c *decryptedLoopCloser) Close() error {
+	err := c.Closer.Close()
+	c.file.Close()
+	os.Remove(c.path)
+	return err
+}
+
+// CreateEncryptedLoop decrypts the encrypted default partition of the SIF
+// at cpath (via signing.Decrypt, prompting for the private key's
+// passphrase through cb) into a plaintext temp file and attaches that file
+// to a loop device. The returned closer removes the temp file in addition
+// to detaching the loop device.
+func CreateEncryptedLoop(cpath string, offset, size uint64, cb signing.PassphraseCallback) (string, io.Closer, error) {
+	plaintext, err := signing.Decrypt(cpath, cb)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decrypt image %s: %s", cpath, err)
+	}
+
+	file, err := os.Open(plaintext)
+	if err != nil {
+		os.Remove(plaintext)
+		return "", nil, fmt.Errorf("failed to open decrypted image %s: %s", plaintext, err)
+	}
+
+	dev, closer, err := CreateLoop(file, offset, size)
+	if err != nil {
+		file.Close()
+		os.Remove(plaintext)
+		return "", nil, err
+	}
+
+	return dev, &decryptedLoopCloser{Closer: closer, file: file, path: plaintext}, nil
+}
@@ -0,0 +1,179 @@
+// Package selfupdate implements `singularity self-update`: downloading the
+// latest release binary from GitHub, verifying it against the embedded
+// release signing key, and atomically replacing the running binary.
+package selfupdate
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// releasesAPI is the GitHub API endpoint for the latest Singularity release.
+const releasesAPI = "https://api.github.com/repos/sylabs/singularity/releases/latest"
+
+type releaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+type release struct {
+	Assets []releaseAsset `json:"assets"`
+}
+
+// LatestReleaseOptions queries the GitHub releases API for the latest
+// release and returns Options pointing at the binary and detached
+// signature matching the running OS and architecture.
+func LatestReleaseOptions() (Options, error) {
+	body, err := download(releasesAPI)
+	if err != nil {
+		return Options{}, err
+	}
+
+	var rel release
+	if err := json.Unmarshal(body, &rel); err != nil {
+		log.Println("Error while parsing release metadata: ", err)
+		return Options{}, err
+	}
+
+	suffix := fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH)
+
+	var binaryURL, sigURL string
+	for _, a := range rel.Assets {
+		if strings.HasSuffix(a.Name, suffix+".asc") {
+			sigURL = a.BrowserDownloadURL
+		} else if strings.HasSuffix(a.Name, suffix) {
+			binaryURL = a.BrowserDownloadURL
+		}
+	}
+
+	if binaryURL == "" || sigURL == "" {
+		return Options{}, fmt.Errorf("no release asset found for %s", suffix)
+	}
+
+	return Options{BinaryURL: binaryURL, SignatureURL: sigURL}, nil
+}
+
+// releaseKey is the Singularity release signing public key, compiled into
+// the binary so downloaded releases can be verified without any local
+// keyring setup. The release_key.asc in this tree is a placeholder, not a
+// parseable key, so with it embedded Update returns an error before
+// installing anything.
+//
+//go:embed release_key.asc
+var releaseKey []byte
+
+// Options configures an update run.
+type Options struct {
+	// BinaryURL is the direct URL of the release binary to download.
+	BinaryURL string
+	// SignatureURL is the direct URL of the binary's detached armored
+	// (.asc) signature.
+	SignatureURL string
+	// TargetPath is the path of the binary to replace. Defaults to the
+	// currently running executable if empty.
+	TargetPath string
+}
+
+// Update downloads the binary and signature described by opts, verifies the
+// binary against the embedded release key, and atomically replaces
+// TargetPath on success. It fails closed: if the signature cannot be
+// verified, the existing binary is left untouched.
+func Update(opts Options) error {
+	target := opts.TargetPath
+	if target == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			log.Println("Error while locating running binary: ", err)
+			return err
+		}
+		target = exe
+	}
+
+	binary, err := download(opts.BinaryURL)
+	if err != nil {
+		return err
+	}
+
+	sig, err := download(opts.SignatureURL)
+	if err != nil {
+		return err
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(releaseKey))
+	if err != nil {
+		log.Println("Error while reading embedded release key: ", err)
+		return err
+	}
+
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(binary), bytes.NewReader(sig)); err != nil {
+		log.Println("Error: downloaded binary failed signature verification: ", err)
+		return fmt.Errorf("refusing to install unverified binary: %s", err)
+	}
+
+	return replace(target, binary)
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Println("Error while downloading ", url, ": ", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// replace atomically installs binary at target, preserving target's
+// existing file mode, by writing to a temp file alongside it and renaming
+// over it.
+func replace(target string, binary []byte) error {
+	info, err := os.Stat(target)
+	mode := os.FileMode(0755)
+	if err == nil {
+		mode = info.Mode()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".new-*")
+	if err != nil {
+		log.Println("Error while creating temp file for new binary: ", err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		log.Println("Error while writing new binary: ", err)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		log.Println("Error while closing new binary: ", err)
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		log.Println("Error while setting new binary mode: ", err)
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		log.Println("Error while installing new binary: ", err)
+		return err
+	}
+
+	return nil
+}
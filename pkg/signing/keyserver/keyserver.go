@@ -0,0 +1,110 @@
+// Package keyserver implements a minimal HKP (HTTP Keyserver Protocol)
+// client used to fetch unknown signer keys on demand during SIF
+// verification.
+package keyserver
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// DefaultKeyserver is used when no keyserver URL is configured.
+const DefaultKeyserver = "https://keys.openpgp.org"
+
+// Client fetches OpenPGP keys from a set of HKP keyservers.
+type Client struct {
+	// Keyservers is tried in order until one returns a key. If empty,
+	// DefaultKeyserver is used.
+	Keyservers []string
+}
+
+// NewClient returns a Client configured to query servers, falling back to
+// DefaultKeyserver if servers is empty.
+func NewClient(servers []string) *Client {
+	if len(servers) == 0 {
+		servers = []string{DefaultKeyserver}
+	}
+	return &Client{Keyservers: servers}
+}
+
+// Fetch retrieves the armored public key matching keyID (e.g. "0x1234ABCD")
+// from the configured keyservers, trying each in turn, and returns the
+// decoded entities.
+func (c *Client) Fetch(keyID string) (openpgp.EntityList, error) {
+	var lastErr error
+
+	servers := c.Keyservers
+	if len(servers) == 0 {
+		servers = []string{DefaultKeyserver}
+	}
+
+	for _, server := range servers {
+		el, err := c.fetchFrom(server, keyID)
+		if err == nil {
+			return el, nil
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("could not fetch key %s from any keyserver: %s", keyID, lastErr)
+}
+
+func (c *Client) fetchFrom(server, keyID string) (openpgp.EntityList, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/pks/lookup"
+	q := u.Query()
+	q.Set("op", "get")
+	q.Set("options", "mr")
+	q.Set("search", keyID)
+	u.RawQuery = q.Encode()
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		log.Println("Error while contacting keyserver: ", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("keyserver %s returned status %s", server, resp.Status)
+	}
+
+	el, err := openpgp.ReadArmoredKeyRing(resp.Body)
+	if err != nil {
+		log.Println("Error while parsing key from keyserver: ", err)
+		return nil, err
+	}
+
+	return el, nil
+}
+
+// ConfirmTrust prints the fingerprint and UIDs of each entity in el and
+// prompts the user on stdin to accept them as trusted.
+func ConfirmTrust(el openpgp.EntityList) (bool, error) {
+	for _, e := range el {
+		fmt.Printf("Fetched key:\n\tFingerprint: %0X\n", e.PrimaryKey.Fingerprint)
+		for _, id := range e.Identities {
+			fmt.Printf("\tUID: %s\n", id.Name)
+		}
+	}
+
+	fmt.Print("Trust and import this key? [y/N] : ")
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	if err := scanner.Err(); err != nil {
+		log.Println("Error while reading trust confirmation: ", err)
+		return false, err
+	}
+
+	answer := scanner.Text()
+	return answer == "y" || answer == "Y" || answer == "yes", nil
+}
@@ -7,12 +7,20 @@ import (
 	"crypto/sha512"
 	"fmt"
 	"github.com/singularityware/singularity/pkg/image"
+	"github.com/singularityware/singularity/pkg/signing/keyserver"
 	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
 	"golang.org/x/crypto/openpgp/clearsign"
 	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/term"
+	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // routine that outputs signature type (applies to vindex operation)
@@ -132,6 +140,27 @@ func SyPgpPathsCheck() error {
 	return nil
 }
 
+// readKeyRing reads a keyring from f, trying the binary OpenPGP format
+// first and falling back to ASCII-armored format (e.g. files produced by
+// `gpg --armor --export`) if the binary parse fails.
+func readKeyRing(f *os.File) (openpgp.EntityList, error) {
+	el, binErr := openpgp.ReadKeyRing(f)
+	if binErr == nil {
+		return el, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, binErr
+	}
+
+	el, armorErr := openpgp.ReadArmoredKeyRing(f)
+	if armorErr != nil {
+		return nil, binErr
+	}
+
+	return el, nil
+}
+
 func SyPgpLoadPrivKeyring() (openpgp.EntityList, error) {
 	if err := SyPgpPathsCheck(); err != nil {
 		return nil, err
@@ -144,7 +173,7 @@ func SyPgpLoadPrivKeyring() (openpgp.EntityList, error) {
 	}
 	defer f.Close()
 
-	el, err := openpgp.ReadKeyRing(f)
+	el, err := readKeyRing(f)
 	if err != nil {
 		log.Println("Error while trying to read secret key ring: ", err)
 		return nil, err
@@ -165,7 +194,7 @@ func SyPgpLoadPubKeyring() (openpgp.EntityList, error) {
 	}
 	defer f.Close()
 
-	el, err := openpgp.ReadKeyRing(f)
+	el, err := readKeyRing(f)
 	if err != nil {
 		log.Println("Error while trying to read public key ring: ", err)
 		return nil, err
@@ -174,6 +203,106 @@ func SyPgpLoadPubKeyring() (openpgp.EntityList, error) {
 	return el, nil
 }
 
+// ExportPublicKey serializes the public key matching fingerprint from the
+// local public keyring as ASCII-armored OpenPGP, suitable for handing to
+// another host so it can verify SIF signatures produced here.
+func ExportPublicKey(fingerprint [20]byte) ([]byte, error) {
+	el, err := SyPgpLoadPubKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range el {
+		if e.PrimaryKey.Fingerprint == fingerprint {
+			var buf bytes.Buffer
+			w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+			if err != nil {
+				log.Println("Error while creating armor encoder: ", err)
+				return nil, err
+			}
+			if err := e.Serialize(w); err != nil {
+				log.Println("Error while serializing public key: ", err)
+				return nil, err
+			}
+			if err := w.Close(); err != nil {
+				log.Println("Error while closing armor encoder: ", err)
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("no public key found with fingerprint %x", fingerprint)
+}
+
+// ImportPublicKey reads an ASCII-armored public key from r and appends it
+// to the local public keyring, so SIF images signed on another host can be
+// verified once only the signer's armored public key has been transferred.
+func ImportPublicKey(r io.Reader) error {
+	if err := SyPgpPathsCheck(); err != nil {
+		return err
+	}
+
+	block, err := armor.Decode(r)
+	if err != nil {
+		log.Println("Error while decoding armored key: ", err)
+		return err
+	}
+	if block.Type != openpgp.PublicKeyType {
+		return fmt.Errorf("expected armored public key, got %q", block.Type)
+	}
+
+	el, err := openpgp.ReadKeyRing(block.Body)
+	if err != nil {
+		log.Println("Error while reading public key: ", err)
+		return err
+	}
+
+	return importEntities(el)
+}
+
+// importEntities appends el to the local public keyring file.
+func importEntities(el openpgp.EntityList) error {
+	if err := SyPgpPathsCheck(); err != nil {
+		return err
+	}
+
+	existing, err := SyPgpLoadPubKeyring()
+	if err != nil {
+		return err
+	}
+
+	fp, err := os.OpenFile(SyPgpPublicPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Println("Could not open public keyring file for appending: ", err)
+		return err
+	}
+	defer fp.Close()
+
+	for _, e := range el {
+		if hasFingerprint(existing, e.PrimaryKey.Fingerprint) {
+			continue
+		}
+		if err := e.Serialize(fp); err != nil {
+			log.Println("Error while writing public entity to keyring file: ", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasFingerprint reports whether el already contains an entity with the
+// given primary key fingerprint.
+func hasFingerprint(el openpgp.EntityList, fingerprint [20]byte) bool {
+	for _, e := range el {
+		if e.PrimaryKey.Fingerprint == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
 func genKeyPair() error {
 	conf := &packet.Config{RSABits: 4096, DefaultHash: crypto.SHA384}
 
@@ -241,17 +370,201 @@ func genKeyPair() error {
 	return nil
 }
 
-// XXX: replace that with acutal cli passwd grab
-func decryptKey(k *openpgp.Entity) error {
-	if k.PrivateKey.Encrypted == true {
-		k.PrivateKey.Decrypt([]byte("devkeys"))
+// PassphraseCallback is called to obtain the passphrase protecting a
+// private key. keyID is the 16-hex-digit key ID of the key being unlocked and
+// prompt is a human-readable message describing why (e.g. to signal a
+// previous attempt failed). It returns the passphrase to try.
+type PassphraseCallback func(keyID string, prompt string) ([]byte, error)
+
+var (
+	passphraseCacheMu sync.Mutex
+	passphraseCache   = map[string][]byte{}
+)
+
+// DefaultPassphraseCallback prompts for a passphrase on the controlling
+// terminal without echoing input.
+func DefaultPassphraseCallback(keyID string, prompt string) ([]byte, error) {
+	if prompt != "" {
+		fmt.Println(prompt)
 	}
-	return nil
+	fmt.Printf("Enter key passphrase for %s : ", keyID)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println("")
+	if err != nil {
+		log.Println("Error while reading passphrase: ", err)
+		return nil, err
+	}
+	return passphrase, nil
 }
 
-// XXX: replace that with actual cli choice maker
+// gpgAgentPassphrase attempts to retrieve the cached passphrase for keyID
+// from a running gpg-agent, for scripted signing where no terminal is
+// available.
+func gpgAgentPassphrase(keyID string) ([]byte, error) {
+	if os.Getenv("GPG_AGENT_INFO") == "" {
+		return nil, fmt.Errorf("GPG_AGENT_INFO not set, no gpg-agent available")
+	}
+
+	cmd := exec.Command("gpg-connect-agent")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("GET_PASSPHRASE --data sypgp-%s X X X\n", keyID))
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("gpg-agent query failed: %s", err)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "D ") {
+			return []byte(strings.TrimPrefix(line, "D ")), nil
+		}
+	}
+
+	return nil, fmt.Errorf("gpg-agent did not return a passphrase")
+}
+
+// decryptKey unlocks k's private key, trying (in order) the in-process
+// cache, the SINGULARITY_PGP_PASSPHRASE environment variable, a running
+// gpg-agent, and finally cb, retrying cb on a wrong passphrase. Successful
+// passphrases are cached in-process, keyed by key ID, for the lifetime of
+// the command.
+func decryptKey(k *openpgp.Entity, cb PassphraseCallback) error {
+	if !k.PrivateKey.Encrypted {
+		return nil
+	}
+
+	keyID := k.PrimaryKey.KeyIdString()
+
+	passphraseCacheMu.Lock()
+	cached, ok := passphraseCache[keyID]
+	passphraseCacheMu.Unlock()
+	if ok {
+		if err := k.PrivateKey.Decrypt(cached); err == nil {
+			return nil
+		}
+	}
+
+	if envPass := os.Getenv("SINGULARITY_PGP_PASSPHRASE"); envPass != "" {
+		if err := k.PrivateKey.Decrypt([]byte(envPass)); err == nil {
+			passphraseCacheMu.Lock()
+			passphraseCache[keyID] = []byte(envPass)
+			passphraseCacheMu.Unlock()
+			return nil
+		}
+	}
+
+	if agentPass, err := gpgAgentPassphrase(keyID); err == nil {
+		if err := k.PrivateKey.Decrypt(agentPass); err == nil {
+			passphraseCacheMu.Lock()
+			passphraseCache[keyID] = agentPass
+			passphraseCacheMu.Unlock()
+			return nil
+		}
+	}
+
+	if cb == nil {
+		cb = DefaultPassphraseCallback
+	}
+
+	prompt := ""
+	for {
+		passphrase, err := cb(keyID, prompt)
+		if err != nil {
+			return err
+		}
+		if err := k.PrivateKey.Decrypt(passphrase); err == nil {
+			passphraseCacheMu.Lock()
+			passphraseCache[keyID] = passphrase
+			passphraseCacheMu.Unlock()
+			return nil
+		}
+		prompt = "Incorrect passphrase, please try again."
+	}
+}
+
+// printKeyChoice prints a numbered menu entry for e -- primary UID, key ID,
+// fingerprint and creation date -- then reuses printSignatures (the same
+// vindex-style output putSigInfo feeds) to list its signatures.
+func printKeyChoice(i int, e *openpgp.Entity) {
+	var name string
+	for _, id := range e.Identities {
+		isPrimary := id.SelfSignature != nil && id.SelfSignature.IsPrimaryId != nil && *id.SelfSignature.IsPrimaryId
+		if name == "" || isPrimary {
+			name = id.Name
+		}
+	}
+	y, m, d := e.PrimaryKey.CreationTime.Date()
+	fmt.Printf("%d)\n", i)
+	fmt.Printf("   U: %s\n", name)
+	fmt.Printf("   K: %s\n", e.PrimaryKey.KeyIdString())
+	fmt.Printf("   F: %0X\n", e.PrimaryKey.Fingerprint)
+	fmt.Printf("   C: %02d-%02d-%02d\n", y, m, d)
+	printSignatures(e)
+}
+
+// selectKey interactively lists the entities in el and prompts the user on
+// stdin to choose one by number.
 func selectKey(el openpgp.EntityList) (*openpgp.Entity, error) {
-	return el[0], nil
+	fmt.Println("Several keys are available, please choose one:")
+	for i, e := range el {
+		printKeyChoice(i, e)
+	}
+
+	fmt.Print("Enter # of key to use : ")
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	if err := scanner.Err(); err != nil {
+		log.Println("Error while reading key choice: ", err)
+		return nil, err
+	}
+
+	choice, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+	if err != nil || choice < 0 || choice >= len(el) {
+		return nil, fmt.Errorf("invalid key choice")
+	}
+
+	return el[choice], nil
+}
+
+// matchesKeyID reports whether e is identified by id, which may be a short
+// key ID, a long key ID, or a full 40-hex fingerprint, matched
+// case-insensitively and with or without a leading "0x".
+func matchesKeyID(e *openpgp.Entity, id string) bool {
+	if id == "" {
+		return false
+	}
+	id = strings.ToUpper(strings.TrimPrefix(id, "0x"))
+
+	fingerprint := fmt.Sprintf("%0X", e.PrimaryKey.Fingerprint)
+	if fingerprint == id {
+		return true
+	}
+
+	keyID := fmt.Sprintf("%016X", e.PrimaryKey.KeyId)
+	if keyID == id || strings.HasSuffix(keyID, id) {
+		return true
+	}
+
+	return false
+}
+
+// selectKeyByID non-interactively picks the entity in el identified by
+// fingerprint (a full 40-hex fingerprint) or keyID (a short or long hex key
+// ID), for automated signing.
+func selectKeyByID(el openpgp.EntityList, fingerprint, keyID string) (*openpgp.Entity, error) {
+	id := fingerprint
+	if id == "" {
+		id = keyID
+	}
+	if id == "" {
+		return nil, fmt.Errorf("no key ID or fingerprint specified")
+	}
+
+	for _, e := range el {
+		if matchesKeyID(e, id) {
+			return e, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no key found matching %q", id)
 }
 
 func SifDataObjectHash(sinfo *image.Sifinfo) (*bytes.Buffer, error) {
@@ -304,7 +617,19 @@ func SifAddSignature(fingerprint [20]byte, sinfo *image.Sifinfo, signature []byt
  * 5) store new hash in SIF
  * 6) record the KeyID used to sign into signature data object descriptor
  */
-func Sign(cpath string) error {
+// SignOpts lets a caller non-interactively target the private key to sign
+// with, instead of being prompted. Exactly one of KeyID or Fingerprint
+// should be set; if both are empty, Sign falls back to interactive
+// selection when more than one private key is available.
+type SignOpts struct {
+	KeyID       string
+	Fingerprint string
+	// Passphrase, if set, is used instead of DefaultPassphraseCallback to
+	// unlock the signing key's private key.
+	Passphrase PassphraseCallback
+}
+
+func Sign(cpath string, opts SignOpts) error {
 	var el openpgp.EntityList
 	var en *openpgp.Entity
 	var err error
@@ -322,14 +647,20 @@ func Sign(cpath string) error {
 		}
 	}
 
-	if len(el) > 1 {
+	if opts.KeyID != "" || opts.Fingerprint != "" {
+		if en, err = selectKeyByID(el, opts.Fingerprint, opts.KeyID); err != nil {
+			return err
+		}
+	} else if len(el) > 1 {
 		if en, err = selectKey(el); err != nil {
 			return err
 		}
 	} else {
 		en = el[0]
 	}
-	decryptKey(en)
+	if err = decryptKey(en, opts.Passphrase); err != nil {
+		return err
+	}
 
 	var sinfo image.Sifinfo
 	if err = image.SifLoad(cpath, &sinfo, 0); err != nil {
@@ -365,8 +696,101 @@ func Sign(cpath string) error {
 	return nil
 }
 
-func Verify(cpath string) error {
-	var el openpgp.EntityList
+// VerifyOpts lets a caller enforce a trust policy on top of plain
+// signature validity, such as requiring signatures from specific
+// fingerprints/key IDs and/or a minimum number of distinct signers.
+type VerifyOpts struct {
+	// RequireKeyIDs, if non-empty, lists key IDs or fingerprints that must
+	// each have produced a valid signature.
+	RequireKeyIDs []string
+	// MinSigners, if greater than zero, is the minimum number of valid,
+	// distinct signers required for verification to succeed.
+	MinSigners int
+	// AutoFetch, when true, fetches unknown signers' public keys from
+	// Keyservers and, once the user confirms trust, imports them into the
+	// local public keyring before retrying verification.
+	AutoFetch bool
+	// Keyservers lists HKP keyserver URLs to query when AutoFetch is set.
+	// Defaults to keyserver.DefaultKeyserver if empty.
+	Keyservers []string
+}
+
+// signatureIssuerKeyID parses sigBody just enough to extract the issuer key
+// ID of the (single) signature packet it contains, without verifying it.
+func signatureIssuerKeyID(sigBody []byte) (uint64, error) {
+	p, err := packet.Read(bytes.NewReader(sigBody))
+	if err != nil {
+		return 0, err
+	}
+
+	sig, ok := p.(*packet.Signature)
+	if !ok || sig.IssuerKeyId == nil {
+		return 0, fmt.Errorf("signature packet has no issuer key ID")
+	}
+
+	return *sig.IssuerKeyId, nil
+}
+
+// verifySignature checks a single clearsign-encoded signature data object
+// against msg and el, returning the signer entity on success. If the
+// signer's key cannot be found in el and opts.AutoFetch is set, it is
+// fetched from a keyserver, and -- once the user confirms trust -- imported
+// into the local public keyring and added to el before retrying.
+// verifySignature checks a single clearsign-encoded signature data object
+// against msg and el. It returns the signer entity and the (possibly
+// extended) keyring the caller should use for subsequent signatures: when
+// opts.AutoFetch fetches a previously-unknown signer key, that key is
+// appended to the returned keyring so later signatures from the same
+// signer within the same Verify call reuse it instead of re-fetching.
+func verifySignature(data []byte, msg *bytes.Buffer, el openpgp.EntityList, opts VerifyOpts) (*openpgp.Entity, openpgp.EntityList, error) {
+	block, _ := clearsign.Decode(data)
+	if block == nil {
+		return nil, el, fmt.Errorf("failed to decode clearsign message")
+	}
+
+	if !bytes.Equal(bytes.TrimRight(block.Plaintext, "\n"), msg.Bytes()) {
+		return nil, el, fmt.Errorf("Sif hash string mismatch -- don't use:\nsigned:     %s\ncalculated: %s", msg.String(), block.Plaintext)
+	}
+
+	sigBody, err := io.ReadAll(block.ArmoredSignature.Body)
+	if err != nil {
+		return nil, el, fmt.Errorf("failed to read signature body: %s", err)
+	}
+
+	signer, err := openpgp.CheckDetachedSignature(el, bytes.NewBuffer(block.Bytes), bytes.NewReader(sigBody))
+	if err == nil {
+		return signer, el, nil
+	}
+
+	if !opts.AutoFetch {
+		return nil, el, fmt.Errorf("failed to check signature: %s", err)
+	}
+
+	keyID, idErr := signatureIssuerKeyID(sigBody)
+	if idErr != nil {
+		return nil, el, fmt.Errorf("failed to check signature: %s", err)
+	}
+
+	fetched, fetchErr := keyserver.NewClient(opts.Keyservers).Fetch(fmt.Sprintf("0x%016X", keyID))
+	if fetchErr != nil {
+		return nil, el, fmt.Errorf("failed to check signature: %s (and could not fetch signer key: %s)", err, fetchErr)
+	}
+
+	trusted, trustErr := keyserver.ConfirmTrust(fetched)
+	if trustErr != nil || !trusted {
+		return nil, el, fmt.Errorf("failed to check signature: %s (fetched signer key was not trusted)", err)
+	}
+
+	if err := importEntities(fetched); err != nil {
+		log.Println("Warning: could not persist fetched key to local keyring: ", err)
+	}
+	el = append(el, fetched...)
+
+	signer, err = openpgp.CheckDetachedSignature(el, bytes.NewBuffer(block.Bytes), bytes.NewReader(sigBody))
+	return signer, el, err
+}
+
+func Verify(cpath string, opts VerifyOpts) error {
 	var sinfo image.Sifinfo
 
 	if err := image.SifLoad(cpath, &sinfo, 0); err != nil {
@@ -380,42 +804,259 @@ func Verify(cpath string) error {
 		return err
 	}
 
-	sig, err := image.SifGetSignature(&sinfo)
+	sigs, err := image.SifGetSignatures(&sinfo)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
 
-	data, err := image.CByteRange(sinfo.Mapstart(), sig.FileOff(), sig.FileLen())
+	el, err := SyPgpLoadPubKeyring()
+	if err != nil {
+		return err
+	}
+
+	var signers []*openpgp.Entity
+	for _, sig := range sigs {
+		data, err := image.CByteRange(sinfo.Mapstart(), sig.FileOff(), sig.FileLen())
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		signer, updatedEl, err := verifySignature(data, msg, el, opts)
+		el = updatedEl
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		signers = append(signers, signer)
+	}
+
+	if len(signers) == 0 {
+		return fmt.Errorf("no valid signatures found")
+	}
+
+	for _, id := range opts.RequireKeyIDs {
+		found := false
+		for _, signer := range signers {
+			if matchesKeyID(signer, id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no valid signature found from required signer %q", id)
+		}
+	}
+
+	distinct := make(map[[20]byte]bool)
+	for _, signer := range signers {
+		distinct[signer.PrimaryKey.Fingerprint] = true
+	}
+	if opts.MinSigners > 0 && len(distinct) < opts.MinSigners {
+		return fmt.Errorf("only %d valid signer(s) found, policy requires at least %d", len(distinct), opts.MinSigners)
+	}
+
+	fmt.Print("Authentic and signed by:\n")
+	for _, signer := range signers {
+		for _, i := range signer.Identities {
+			fmt.Printf("\t%s\n", i.Name)
+		}
+	}
+
+	return nil
+}
+
+// getKeyByEmail returns the first entity in el that has a UID whose email
+// address matches email.
+func getKeyByEmail(el openpgp.EntityList, email string) (*openpgp.Entity, error) {
+	for _, e := range el {
+		for _, id := range e.Identities {
+			if id.UserId != nil && id.UserId.Email == email {
+				return e, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no key found for email %q", email)
+}
+
+// resolveRecipient finds the entity in el matching recipient, which may be
+// an email address, short/long key ID, or full fingerprint.
+func resolveRecipient(el openpgp.EntityList, recipient string) (*openpgp.Entity, error) {
+	if e, err := getKeyByEmail(el, recipient); err == nil {
+		return e, nil
+	}
+	return selectKeyByID(el, recipient, recipient)
+}
+
+// Encrypt PGP-encrypts the default partition's data object to recipients
+// (each an email address, key ID, or fingerprint looked up in the local
+// public keyring) and stores the ciphertext as a new SIF data object,
+// giving the image's payload at-rest confidentiality.
+func Encrypt(cpath string, recipients []string) error {
+	if len(recipients) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+
+	el, err := SyPgpLoadPubKeyring()
 	if err != nil {
+		return err
+	}
+
+	recipientEntities := make([]*openpgp.Entity, 0, len(recipients))
+	for _, r := range recipients {
+		e, err := resolveRecipient(el, r)
+		if err != nil {
+			return fmt.Errorf("could not resolve recipient %q: %s", r, err)
+		}
+		recipientEntities = append(recipientEntities, e)
+	}
+
+	var sinfo image.Sifinfo
+	if err := image.SifLoad(cpath, &sinfo, 0); err != nil {
 		log.Println(err)
 		return err
 	}
+	defer image.SifUnload(&sinfo)
 
-	block, _ := clearsign.Decode(data)
-	if block == nil {
-		log.Printf("failed to decode clearsign message\n")
-		return fmt.Errorf("failed to decode clearsign message\n")
+	part, err := image.SifGetPartition(&sinfo, image.SIF_DEFAULT_GROUP)
+	if err != nil {
+		log.Println(err)
+		return err
 	}
 
-	if !bytes.Equal(bytes.TrimRight(block.Plaintext, "\n"), msg.Bytes()) {
-		log.Printf("Sif hash string mismatch -- don't use:\nsigned:     %s\ncalculated: %s", msg.String(), block.Plaintext)
-		return fmt.Errorf("Sif hash string mismatch -- don't use")
+	data, err := image.CByteRange(sinfo.Mapstart(), part.FileOff(), part.FileLen())
+	if err != nil {
+		log.Println(err)
+		return err
 	}
 
-	if el, err = SyPgpLoadPubKeyring(); err != nil {
+	var ciphertext bytes.Buffer
+	w, err := openpgp.Encrypt(&ciphertext, recipientEntities, nil, nil, nil)
+	if err != nil {
+		log.Println("Error while setting up encryption: ", err)
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		log.Println("Error while encrypting data: ", err)
+		return err
+	}
+	if err := w.Close(); err != nil {
+		log.Println("Error while finalizing encryption: ", err)
 		return err
 	}
 
-	var signer *openpgp.Entity
-	if signer, err = openpgp.CheckDetachedSignature(el, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body); err != nil {
-		log.Printf("failed to check signature: %s", err)
+	// The plaintext partition object must not survive encryption, or the
+	// image would carry its own cleartext payload alongside the
+	// "encrypted" one, defeating at-rest confidentiality entirely.
+	// SifDeleteDataObj only drops the descriptor, so the plaintext bytes are
+	// wiped in place first.
+	for i := range data {
+		data[i] = 0
+	}
+	if err := image.SifDeleteDataObj(&sinfo, part); err != nil {
+		log.Println(err)
 		return err
 	}
-	fmt.Print("Authentic and signed by:\n")
-	for _, i := range signer.Identities {
-		fmt.Printf("\t%s\n", i.Name)
+
+	var e image.Eleminfo
+	e.InitEncryption(ciphertext.Bytes(), part)
+
+	if err := image.SifPutDataObj(&e, &sinfo); err != nil {
+		log.Println(err)
+		return err
 	}
 
 	return nil
 }
+
+// Decrypt locates the encrypted data object added by Encrypt, decrypts it
+// using the local private keyring (unlocked via cb, the same
+// PassphraseCallback used by Sign), and writes the plaintext to a new
+// temporary file whose path is returned. The caller is responsible for
+// removing the file once it is no longer needed, e.g. after handing it to
+// tools.CreateLoop.
+func Decrypt(cpath string, cb PassphraseCallback) (string, error) {
+	el, err := SyPgpLoadPrivKeyring()
+	if err != nil {
+		return "", err
+	}
+
+	var sinfo image.Sifinfo
+	if err := image.SifLoad(cpath, &sinfo, 0); err != nil {
+		log.Println(err)
+		return "", err
+	}
+	defer image.SifUnload(&sinfo)
+
+	enc, err := image.SifGetEncryption(&sinfo)
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	data, err := image.CByteRange(sinfo.Mapstart(), enc.FileOff(), enc.FileLen())
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	promptFn := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+		var lastErr error
+		unlocked := false
+		for _, k := range keys {
+			if k.PrivateKey == nil || !k.PrivateKey.Encrypted {
+				unlocked = true
+				continue
+			}
+			if err := decryptKey(k.Entity, cb); err != nil {
+				lastErr = err
+				continue
+			}
+			// k may be an encryption subkey, which decryptKey (acting on the
+			// primary key) leaves locked; unlock it with the cached passphrase.
+			if k.PrivateKey.Encrypted {
+				passphraseCacheMu.Lock()
+				passphrase := passphraseCache[k.Entity.PrimaryKey.KeyIdString()]
+				passphraseCacheMu.Unlock()
+				if err := k.PrivateKey.Decrypt(passphrase); err != nil {
+					lastErr = err
+					continue
+				}
+			}
+			unlocked = true
+		}
+		if unlocked {
+			return nil, nil
+		}
+		// No key could be unlocked: tell openpgp.ReadMessage's key-finding
+		// loop to give up instead of calling prompt again indefinitely.
+		return nil, lastErr
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(data), el, promptFn, nil)
+	if err != nil {
+		log.Println("Error while opening encrypted message: ", err)
+		return "", err
+	}
+
+	plaintext, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		log.Println("Error while decrypting data: ", err)
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp("", "sypgp-decrypted-*")
+	if err != nil {
+		log.Println("Error while creating plaintext temp file: ", err)
+		return "", err
+	}
+	defer tmp.Close()
+
+	if _, err := tmp.Write(plaintext); err != nil {
+		log.Println("Error while writing plaintext temp file: ", err)
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2019-2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package signing
+
+import (
+	"crypto"
+	_ "crypto/sha256"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+var errAborted = errors.New("aborted")
+
+func entityWithKey(fingerprint [20]byte, keyID uint64) *openpgp.Entity {
+	return &openpgp.Entity{
+		PrimaryKey: &packet.PublicKey{
+			Fingerprint: fingerprint,
+			KeyId:       keyID,
+		},
+	}
+}
+
+func TestMatchesKeyID(t *testing.T) {
+	fingerprint := [20]byte{0xAB, 0xCD, 0xEF, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10, 0x11}
+	e := entityWithKey(fingerprint, 0x0203040506070809)
+
+	tests := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{"empty", "", false},
+		{"full fingerprint", "ABCDEF0102030405060708090A0B0C0D0E0F1011", true},
+		{"full fingerprint lowercase", "abcdef0102030405060708090a0b0c0d0e0f1011", true},
+		{"fingerprint with 0x prefix", "0xABCDEF0102030405060708090A0B0C0D0E0F1011", true},
+		{"long key id", "0203040506070809", true},
+		{"short key id", "06070809", true},
+		{"unrelated id", "DEADBEEFDEADBEEF", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesKeyID(e, tt.id); got != tt.want {
+				t.Errorf("matchesKeyID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectKeyByID(t *testing.T) {
+	e1 := entityWithKey([20]byte{0x01}, 0x1111111111111111)
+	e2 := entityWithKey([20]byte{0x02}, 0x2222222222222222)
+	el := openpgp.EntityList{e1, e2}
+
+	if got, err := selectKeyByID(el, "", "2222222222222222"); err != nil || got != e2 {
+		t.Errorf("selectKeyByID by long key ID = %v, %v; want e2, nil", got, err)
+	}
+
+	fp := "0100000000000000000000000000000000000000"
+	if got, err := selectKeyByID(el, fp, ""); err != nil || got != e1 {
+		t.Errorf("selectKeyByID by fingerprint = %v, %v; want e1, nil", got, err)
+	}
+
+	if _, err := selectKeyByID(el, "", ""); err == nil {
+		t.Error("selectKeyByID with no id or fingerprint should error")
+	}
+
+	if _, err := selectKeyByID(el, "", "deadbeefdeadbeef"); err == nil {
+		t.Error("selectKeyByID with unknown key ID should error")
+	}
+}
+
+func newEncryptedTestEntity(t *testing.T, passphrase []byte) *openpgp.Entity {
+	t.Helper()
+
+	conf := &packet.Config{RSABits: 1024, DefaultHash: crypto.SHA256}
+	e, err := openpgp.NewEntity("Test User", "", "test@example.com", conf)
+	if err != nil {
+		t.Fatalf("failed to generate test entity: %s", err)
+	}
+	if err := e.PrivateKey.Encrypt(passphrase); err != nil {
+		t.Fatalf("failed to encrypt test private key: %s", err)
+	}
+	return e
+}
+
+func TestDecryptKeyPassphrasePrecedence(t *testing.T) {
+	const passphrase = "correct horse battery staple"
+
+	t.Run("cache hit", func(t *testing.T) {
+		e := newEncryptedTestEntity(t, []byte(passphrase))
+		keyID := e.PrimaryKey.KeyIdString()
+
+		passphraseCacheMu.Lock()
+		passphraseCache[keyID] = []byte(passphrase)
+		passphraseCacheMu.Unlock()
+		t.Cleanup(func() {
+			passphraseCacheMu.Lock()
+			delete(passphraseCache, keyID)
+			passphraseCacheMu.Unlock()
+		})
+
+		called := false
+		cb := func(keyID, prompt string) ([]byte, error) {
+			called = true
+			return nil, nil
+		}
+
+		if err := decryptKey(e, cb); err != nil {
+			t.Fatalf("decryptKey with cached passphrase failed: %s", err)
+		}
+		if called {
+			t.Error("decryptKey should not invoke the callback when the passphrase is cached")
+		}
+	})
+
+	t.Run("env var", func(t *testing.T) {
+		e := newEncryptedTestEntity(t, []byte(passphrase))
+		keyID := e.PrimaryKey.KeyIdString()
+
+		t.Setenv("SINGULARITY_PGP_PASSPHRASE", passphrase)
+		t.Cleanup(func() {
+			passphraseCacheMu.Lock()
+			delete(passphraseCache, keyID)
+			passphraseCacheMu.Unlock()
+		})
+
+		called := false
+		cb := func(keyID, prompt string) ([]byte, error) {
+			called = true
+			return nil, nil
+		}
+
+		if err := decryptKey(e, cb); err != nil {
+			t.Fatalf("decryptKey with env var passphrase failed: %s", err)
+		}
+		if called {
+			t.Error("decryptKey should not invoke the callback when the env var passphrase is correct")
+		}
+	})
+
+	t.Run("callback fallback retries on wrong passphrase", func(t *testing.T) {
+		e := newEncryptedTestEntity(t, []byte(passphrase))
+		keyID := e.PrimaryKey.KeyIdString()
+		t.Cleanup(func() {
+			passphraseCacheMu.Lock()
+			delete(passphraseCache, keyID)
+			passphraseCacheMu.Unlock()
+		})
+
+		attempts := 0
+		cb := func(keyID, prompt string) ([]byte, error) {
+			attempts++
+			if attempts == 1 {
+				return []byte("wrong passphrase"), nil
+			}
+			return []byte(passphrase), nil
+		}
+
+		if err := decryptKey(e, cb); err != nil {
+			t.Fatalf("decryptKey via callback failed: %s", err)
+		}
+		if attempts != 2 {
+			t.Errorf("expected decryptKey to retry once after a wrong passphrase, got %d attempts", attempts)
+		}
+	})
+
+	t.Run("callback error aborts", func(t *testing.T) {
+		e := newEncryptedTestEntity(t, []byte(passphrase))
+		keyID := e.PrimaryKey.KeyIdString()
+		t.Cleanup(func() {
+			passphraseCacheMu.Lock()
+			delete(passphraseCache, keyID)
+			passphraseCacheMu.Unlock()
+		})
+
+		cb := func(keyID, prompt string) ([]byte, error) {
+			return nil, errAborted
+		}
+
+		if err := decryptKey(e, cb); err != errAborted {
+			t.Fatalf("decryptKey should propagate the callback's error, got %v", err)
+		}
+	})
+}
+
+func TestResolveRecipient(t *testing.T) {
+	byEmail := newEncryptedTestEntity(t, []byte("x"))
+	byKeyID := entityWithKey([20]byte{0x09}, 0x0909090909090909)
+	el := openpgp.EntityList{byEmail, byKeyID}
+
+	e, err := resolveRecipient(el, "test@example.com")
+	if err != nil || e != byEmail {
+		t.Errorf("resolveRecipient by email = %v, %v; want byEmail, nil", e, err)
+	}
+
+	e, err = resolveRecipient(el, "0909090909090909")
+	if err != nil || e != byKeyID {
+		t.Errorf("resolveRecipient by key ID = %v, %v; want byKeyID, nil", e, err)
+	}
+
+	if _, err := resolveRecipient(el, "nobody@example.com"); err == nil {
+		t.Error("resolveRecipient with unknown recipient should error")
+	}
+}